@@ -0,0 +1,149 @@
+package httpclient
+
+import (
+	"sync"
+	"time"
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// circuitBreaker tracks the most recent requests for a single host in a
+// fixed-size ring buffer and trips to the open state once the failure
+// rate over that window crosses threshold, short-circuiting further
+// calls until coolDown has elapsed. The window (rather than a lifetime
+// total) is what lets a long-lived client still trip on a fresh
+// sustained outage after it has already served a large amount of
+// healthy traffic.
+type circuitBreaker struct {
+	mu sync.Mutex
+
+	state    breakerState
+	openedAt time.Time
+
+	threshold  float64
+	minSamples int
+	coolDown   time.Duration
+
+	outcomes []bool // ring buffer; true = failure
+	idx      int
+	filled   int
+	failures int
+}
+
+func newCircuitBreaker(threshold float64, minSamples int, coolDown time.Duration, window int) *circuitBreaker {
+	return &circuitBreaker{
+		threshold:  threshold,
+		minSamples: minSamples,
+		coolDown:   coolDown,
+		outcomes:   make([]bool, window),
+	}
+}
+
+// allow reports whether a request may proceed, moving an open breaker
+// to half-open once the cool-down window has elapsed.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerOpen {
+		if time.Since(b.openedAt) < b.coolDown {
+			return false
+		}
+		b.state = breakerHalfOpen
+	}
+	return true
+}
+
+func (b *circuitBreaker) recordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerHalfOpen {
+		b.reset()
+		return
+	}
+	b.record(false)
+}
+
+func (b *circuitBreaker) recordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerHalfOpen {
+		b.trip()
+		return
+	}
+
+	b.record(true)
+	if b.filled >= b.minSamples && float64(b.failures)/float64(b.filled) >= b.threshold {
+		b.trip()
+	}
+}
+
+// record pushes isFailure into the ring buffer, evicting the oldest
+// sample once the window is full so failures/filled always reflects
+// only the most recent len(outcomes) requests.
+func (b *circuitBreaker) record(isFailure bool) {
+	if b.filled < len(b.outcomes) {
+		b.filled++
+	} else if b.outcomes[b.idx] {
+		b.failures--
+	}
+	b.outcomes[b.idx] = isFailure
+	if isFailure {
+		b.failures++
+	}
+	b.idx = (b.idx + 1) % len(b.outcomes)
+}
+
+func (b *circuitBreaker) trip() {
+	b.state = breakerOpen
+	b.openedAt = time.Now()
+}
+
+func (b *circuitBreaker) reset() {
+	b.state = breakerClosed
+	b.idx = 0
+	b.filled = 0
+	b.failures = 0
+}
+
+// breakerRegistry hands out a circuit breaker per host, creating one
+// lazily on first use.
+type breakerRegistry struct {
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+
+	threshold  float64
+	minSamples int
+	coolDown   time.Duration
+	window     int
+}
+
+func newBreakerRegistry(threshold float64, minSamples int, coolDown time.Duration, window int) *breakerRegistry {
+	return &breakerRegistry{
+		breakers:   make(map[string]*circuitBreaker),
+		threshold:  threshold,
+		minSamples: minSamples,
+		coolDown:   coolDown,
+		window:     window,
+	}
+}
+
+func (r *breakerRegistry) forHost(host string) *circuitBreaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.breakers[host]
+	if !ok {
+		b = newCircuitBreaker(r.threshold, r.minSamples, r.coolDown, r.window)
+		r.breakers[host] = b
+	}
+	return b
+}
@@ -0,0 +1,43 @@
+package httpclient
+
+import "testing"
+
+// TestCircuitBreakerRollingWindow guards against the failure ratio being
+// computed over the breaker's lifetime: a host with a long healthy
+// history must still trip on a fresh run of failures once that history
+// has aged out of the window.
+func TestCircuitBreakerRollingWindow(t *testing.T) {
+	b := newCircuitBreaker(0.5, 5, 0, 10)
+
+	for i := 0; i < 1000; i++ {
+		b.recordSuccess()
+	}
+	if b.state != breakerClosed {
+		t.Fatalf("expected closed after all-success history, got %v", b.state)
+	}
+
+	for i := 0; i < 5; i++ {
+		b.recordFailure()
+	}
+	if b.state != breakerOpen {
+		t.Fatalf("expected breaker to trip on a sustained outage despite prior healthy traffic, got %v", b.state)
+	}
+}
+
+func TestCircuitBreakerHalfOpenResetsWindow(t *testing.T) {
+	b := newCircuitBreaker(0.5, 2, 0, 4)
+	b.recordFailure()
+	b.recordFailure()
+	if b.state != breakerOpen {
+		t.Fatalf("expected open, got %v", b.state)
+	}
+
+	b.state = breakerHalfOpen
+	b.recordSuccess()
+	if b.state != breakerClosed {
+		t.Fatalf("expected half-open success to close the breaker, got %v", b.state)
+	}
+	if b.filled != 0 || b.failures != 0 {
+		t.Fatalf("expected reset to clear the window, got filled=%d failures=%d", b.filled, b.failures)
+	}
+}
@@ -5,22 +5,77 @@ import (
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
+	"sync"
 	"time"
 	"fmt"
+
+	"golang.org/x/net/publicsuffix"
 )
 
 type Config struct{
 	Timeout time.Duration
 	ProxyURL string
+	// ProxyPool, if set, takes priority over ProxyURL and routes each
+	// request through Transport.Proxy per the pool's rotation policy.
+	ProxyPool *ProxyPool
 	BaseHeaders map[string]string
 	MaxIdleConns int
 	MaxIdleConnsPerHost int
 	IdleConnTimeout time.Duration
+
+	// MaxRetries is the number of retry attempts after the initial try.
+	// Zero (the default) disables retries entirely.
+	MaxRetries int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay time.Duration
+	// RetryableStatuses defaults to 408, 429, 500, 502, 503, 504.
+	RetryableStatuses []int
+	// RespectRetryAfter honors a Retry-After response header in place
+	// of the computed backoff delay.
+	RespectRetryAfter bool
+
+	// CircuitBreakerThreshold is the failure rate (0-1) at which the
+	// per-host breaker trips open. Zero (the default) disables the
+	// breaker.
+	CircuitBreakerThreshold float64
+	// CircuitBreakerMinSamples is the minimum number of requests observed
+	// before the failure rate is evaluated. Defaults to 5.
+	CircuitBreakerMinSamples int
+	// CircuitBreakerCoolDown is how long an open breaker waits before
+	// allowing a half-open probe request. Defaults to 30s.
+	CircuitBreakerCoolDown time.Duration
+	// CircuitBreakerWindow is how many of the most recent requests the
+	// failure rate is computed over. Defaults to the larger of
+	// CircuitBreakerMinSamples*4 and 20, so a long-lived client's
+	// lifetime traffic can't dilute a fresh sustained outage below
+	// threshold.
+	CircuitBreakerWindow int
+
+	// MaxResponseBytes caps how much of a response body Do/Get/Post/
+	// PostJSON/Request will read into memory. Zero means no cap.
+	MaxResponseBytes int64
+	// Debug captures the wire request/response via httputil.DumpRequestOut
+	// / DumpResponse and exposes them on the returned *Response.
+	Debug bool
 }
 
 type Client struct{
 	http *http.Client
 	baseHeaders map[string]string
+	retryCfg retryConfig
+	breakers *breakerRegistry
+	proxyPool *ProxyPool
+	maxResponseBytes int64
+	debug bool
+
+	// cookieHosts tracks the scheme+host pairs this Client has touched
+	// so SaveCookies/SaveCookiesNetscape know which to ask the jar
+	// about: net/http/cookiejar has no way to enumerate its own
+	// domains. Keyed by "scheme://host" since a host reached over both
+	// http and https (e.g. via a redirect to TLS) can hold distinct
+	// Secure-flagged cookies under each scheme.
+	cookieHostsMu sync.Mutex
+	cookieHosts   map[string]struct{}
 }
 
 
@@ -37,8 +92,29 @@ func New(cfg Config) (*Client, error) {
 	if cfg.IdleConnTimeout == 0 {
 		cfg.IdleConnTimeout = 90 * time.Second
 	}
+	if cfg.RetryBaseDelay == 0 {
+		cfg.RetryBaseDelay = 200 * time.Millisecond
+	}
+	if cfg.RetryMaxDelay == 0 {
+		cfg.RetryMaxDelay = 5 * time.Second
+	}
+	if len(cfg.RetryableStatuses) == 0 {
+		cfg.RetryableStatuses = []int{408, 429, 500, 502, 503, 504}
+	}
+	if cfg.CircuitBreakerMinSamples == 0 {
+		cfg.CircuitBreakerMinSamples = 5
+	}
+	if cfg.CircuitBreakerCoolDown == 0 {
+		cfg.CircuitBreakerCoolDown = 30 * time.Second
+	}
+	if cfg.CircuitBreakerWindow == 0 {
+		cfg.CircuitBreakerWindow = cfg.CircuitBreakerMinSamples * 4
+		if cfg.CircuitBreakerWindow < 20 {
+			cfg.CircuitBreakerWindow = 20
+		}
+	}
 
-	jar, _ := cookiejar.New(nil)
+	jar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
 
 	tr := &http.Transport{
 		DialContext: (&net.Dialer{
@@ -50,9 +126,18 @@ func New(cfg Config) (*Client, error) {
 		IdleConnTimeout:     cfg.IdleConnTimeout,
 		TLSHandshakeTimeout: 10 * time.Second,
 		Proxy:              http.ProxyFromEnvironment,
+		// For a request sent without an Accept-Encoding header, the
+		// default Transport adds Accept-Encoding: gzip itself and then
+		// transparently decompresses the body, stripping
+		// Content-Encoding. Disable that so Content-Encoding is always
+		// left for the client's own gzip/deflate/br handling in
+		// wrapResponse.
+		DisableCompression: true,
 	}
 
-	if cfg.ProxyURL != "" {
+	if cfg.ProxyPool != nil {
+		tr.Proxy = cfg.ProxyPool.Proxy
+	} else if cfg.ProxyURL != "" {
 		p, err := url.Parse(cfg.ProxyURL)
 		if err != nil {
 			return nil, err
@@ -67,8 +152,17 @@ func New(cfg Config) (*Client, error) {
 			Jar:       jar,
 		},
 		baseHeaders: map[string]string{
-			"User-Agent": "Mozilla/5.0",
+			"User-Agent":      "Mozilla/5.0",
+			"Accept-Encoding": "gzip, deflate, br",
 		},
+		retryCfg: newRetryConfig(cfg),
+		proxyPool: cfg.ProxyPool,
+		maxResponseBytes: cfg.MaxResponseBytes,
+		debug: cfg.Debug,
+	}
+
+	if cfg.CircuitBreakerThreshold > 0 {
+		c.breakers = newBreakerRegistry(cfg.CircuitBreakerThreshold, cfg.CircuitBreakerMinSamples, cfg.CircuitBreakerCoolDown, cfg.CircuitBreakerWindow)
 	}
 
 	for k, v := range cfg.BaseHeaders {
@@ -78,6 +172,17 @@ func New(cfg Config) (*Client, error) {
 	return c, nil
 }
 
+// recordVisited notes that u's host has been used with this Client, so
+// SaveCookies/SaveCookiesNetscape know to ask the jar about it later.
+func (c *Client) recordVisited(u *url.URL) {
+	c.cookieHostsMu.Lock()
+	defer c.cookieHostsMu.Unlock()
+	if c.cookieHosts == nil {
+		c.cookieHosts = map[string]struct{}{}
+	}
+	c.cookieHosts[u.Scheme+"://"+u.Host] = struct{}{}
+}
+
 func (c *Client) SetCookie(rawURL, name, value string) error {
 	u, err := url.Parse(rawURL)
 	if err != nil {
@@ -86,6 +191,7 @@ func (c *Client) SetCookie(rawURL, name, value string) error {
 	c.http.Jar.SetCookies(u, []*http.Cookie{
 		{Name: name, Value: value, Path: "/"},
 	})
+	c.recordVisited(u)
 	return nil
 }
 
@@ -0,0 +1,238 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Encoder serializes v onto w for a registered content type.
+type Encoder interface {
+	Encode(w io.Writer, v any) error
+}
+
+// Decoder deserializes r into v for a registered content type.
+type Decoder interface {
+	Decode(r io.Reader, v any) error
+}
+
+var (
+	encodersMu sync.RWMutex
+	encoders   = map[string]Encoder{
+		"application/json":                  jsonCodec{},
+		"application/xml":                   xmlCodec{},
+		"application/x-www-form-urlencoded": formCodec{},
+	}
+
+	decodersMu sync.RWMutex
+	decoders   = map[string]Decoder{
+		"application/json": jsonCodec{},
+		"application/xml":  xmlCodec{},
+	}
+)
+
+// RegisterEncoder registers (or overrides) the Encoder used for
+// contentType, e.g. to plug in protobuf or msgpack. Safe for concurrent
+// use.
+func RegisterEncoder(contentType string, enc Encoder) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	encoders[contentType] = enc
+}
+
+// RegisterDecoder registers (or overrides) the Decoder used for
+// contentType. Safe for concurrent use.
+func RegisterDecoder(contentType string, dec Decoder) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	decoders[contentType] = dec
+}
+
+func encoderFor(contentType string) (Encoder, error) {
+	base := baseMediaType(contentType)
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	enc, ok := encoders[base]
+	if !ok {
+		return nil, fmt.Errorf("httpclient: no encoder registered for %q", contentType)
+	}
+	return enc, nil
+}
+
+func decoderFor(contentType string) (Decoder, error) {
+	base := baseMediaType(contentType)
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	dec, ok := decoders[base]
+	if !ok {
+		return nil, fmt.Errorf("httpclient: no decoder registered for %q", contentType)
+	}
+	return dec, nil
+}
+
+func baseMediaType(contentType string) string {
+	if contentType == "" {
+		return "application/json"
+	}
+	base, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+	return base
+}
+
+// bufferPool lets hot-path callers (Request's JSON encoding in
+// particular) reuse a bytes.Buffer instead of allocating a new one per
+// call.
+var bufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// GetBuffer returns an empty buffer from the shared pool.
+func GetBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// PutBuffer returns buf to the shared pool for reuse. Callers must not
+// touch buf afterwards.
+func PutBuffer(buf *bytes.Buffer) {
+	bufferPool.Put(buf)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Encode(w io.Writer, v any) error { return json.NewEncoder(w).Encode(v) }
+func (jsonCodec) Decode(r io.Reader, v any) error  { return json.NewDecoder(r).Decode(v) }
+
+type xmlCodec struct{}
+
+func (xmlCodec) Encode(w io.Writer, v any) error { return xml.NewEncoder(w).Encode(v) }
+func (xmlCodec) Decode(r io.Reader, v any) error  { return xml.NewDecoder(r).Decode(v) }
+
+// formCodec encodes a url.Values or map[string]string as
+// application/x-www-form-urlencoded. Decoding isn't meaningful for this
+// content type so Decode always errors.
+type formCodec struct{}
+
+func (formCodec) Encode(w io.Writer, v any) error {
+	values, err := toURLValues(v)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, values.Encode())
+	return err
+}
+
+func (formCodec) Decode(r io.Reader, v any) error {
+	return errors.New("httpclient: form decoding is not supported")
+}
+
+func toURLValues(v any) (url.Values, error) {
+	switch t := v.(type) {
+	case url.Values:
+		return t, nil
+	case map[string]string:
+		values := make(url.Values, len(t))
+		for k, val := range t {
+			values.Set(k, val)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("httpclient: form codec does not support %T", v)
+	}
+}
+
+// Files maps a multipart field name to a path on disk to upload.
+type Files map[string]string
+
+// MultipartForm is the payload Client.Request understands for
+// multipart/form-data: plain Fields, Files read from disk, and Streams
+// read from an arbitrary io.Reader (for data not backed by a file).
+type MultipartForm struct {
+	Fields  map[string]string
+	Files   Files
+	Streams map[string]io.Reader
+}
+
+// writeMultipartForm writes form's fields, files, and streams onto mw
+// and closes it, without touching the underlying writer otherwise. It's
+// shared by encodeMultipart (small, buffered forms) and streamMultipart
+// (large, piped forms) so both stay in sync.
+func writeMultipartForm(mw *multipart.Writer, form MultipartForm) error {
+	for k, v := range form.Fields {
+		if err := mw.WriteField(k, v); err != nil {
+			return err
+		}
+	}
+	for field, path := range form.Files {
+		if err := writeMultipartFile(mw, field, path); err != nil {
+			return err
+		}
+	}
+	for field, r := range form.Streams {
+		part, err := mw.CreateFormFile(field, field)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(part, r); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+// encodeMultipart writes form to w and returns the Content-Type header
+// value (including the generated boundary) to send with it.
+func encodeMultipart(w io.Writer, form MultipartForm) (string, error) {
+	mw := multipart.NewWriter(w)
+	if err := writeMultipartForm(mw, form); err != nil {
+		return "", err
+	}
+	return mw.FormDataContentType(), nil
+}
+
+// streamMultipart encodes form into an *io.PipeReader fed by a
+// background goroutine instead of a buffer, so a MultipartForm with
+// large Files/Streams does not have to sit fully in memory here (and
+// never round-trips through the shared buffer pool, which would
+// otherwise hand that oversized backing array back out to an unrelated
+// small encode). When Config.MaxRetries is set, doRaw still reads the
+// whole piped body into memory so it can be replayed. Returns the body
+// to send and its Content-Type.
+func streamMultipart(form MultipartForm) (io.Reader, string) {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	contentType := mw.FormDataContentType()
+
+	go func() {
+		pw.CloseWithError(writeMultipartForm(mw, form))
+	}()
+
+	return pr, contentType
+}
+
+func writeMultipartFile(mw *multipart.Writer, field, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	part, err := mw.CreateFormFile(field, filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, f)
+	return err
+}
@@ -0,0 +1,176 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+type widget struct {
+	Name  string `json:"name" xml:"name"`
+	Count int    `json:"count" xml:"count"`
+}
+
+// TestRequestJSONRoundTrip exercises the default JSON codec through
+// Client.Request end to end against a real server.
+func TestRequestJSONRoundTrip(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", ct)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name":"gizmo","count":3}`))
+	}))
+	defer srv.Close()
+
+	c, err := New(Config{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	var out widget
+	if _, err := c.Request(context.Background(), http.MethodPost, srv.URL, widget{Name: "gadget", Count: 1}, &out, Options{}); err != nil {
+		t.Fatalf("Request: %v", err)
+	}
+	if out.Name != "gizmo" || out.Count != 3 {
+		t.Fatalf("got %+v, want {gizmo 3}", out)
+	}
+}
+
+// TestRequestXMLRoundTrip exercises the built-in XML codec.
+func TestRequestXMLRoundTrip(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/xml" {
+			t.Errorf("Content-Type = %q, want application/xml", ct)
+		}
+		var in widget
+		if err := xml.NewDecoder(r.Body).Decode(&in); err != nil {
+			t.Errorf("server decode: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		xml.NewEncoder(w).Encode(in)
+	}))
+	defer srv.Close()
+
+	c, err := New(Config{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	var out widget
+	opt := Options{ContentType: "application/xml"}
+	if _, err := c.Request(context.Background(), http.MethodPost, srv.URL, widget{Name: "gadget", Count: 7}, &out, opt); err != nil {
+		t.Fatalf("Request: %v", err)
+	}
+	if out.Name != "gadget" || out.Count != 7 {
+		t.Fatalf("got %+v, want {gadget 7}", out)
+	}
+}
+
+// TestRequestMultipartForm verifies a MultipartForm with Fields, Files,
+// and Streams arrives at the server intact, streamed rather than
+// buffered (see streamMultipart).
+func TestRequestMultipartForm(t *testing.T) {
+	tmp := t.TempDir() + "/upload.txt"
+	if err := os.WriteFile(tmp, []byte("file contents"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("ParseMultipartForm: %v", err)
+		}
+		if got := r.FormValue("title"); got != "hello" {
+			t.Errorf("title field = %q, want hello", got)
+		}
+		f, _, err := r.FormFile("doc")
+		if err != nil {
+			t.Fatalf("FormFile(doc): %v", err)
+		}
+		defer f.Close()
+		docBytes, _ := io.ReadAll(f)
+		if string(docBytes) != "file contents" {
+			t.Errorf("doc contents = %q", docBytes)
+		}
+
+		s, _, err := r.FormFile("blob")
+		if err != nil {
+			t.Fatalf("FormFile(blob): %v", err)
+		}
+		defer s.Close()
+		blobBytes, _ := io.ReadAll(s)
+		if string(blobBytes) != "stream bytes" {
+			t.Errorf("blob contents = %q", blobBytes)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c, err := New(Config{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	form := MultipartForm{
+		Fields:  map[string]string{"title": "hello"},
+		Files:   Files{"doc": tmp},
+		Streams: map[string]io.Reader{"blob": strings.NewReader("stream bytes")},
+	}
+
+	if _, err := c.Request(context.Background(), http.MethodPost, srv.URL, form, nil, Options{}); err != nil {
+		t.Fatalf("Request: %v", err)
+	}
+}
+
+// upperCodec is a minimal custom Encoder/Decoder used to verify
+// RegisterEncoder/RegisterDecoder plug in the same way the built-ins do.
+type upperCodec struct{}
+
+func (upperCodec) Encode(w io.Writer, v any) error {
+	_, err := io.WriteString(w, strings.ToUpper(v.(string)))
+	return err
+}
+
+func (upperCodec) Decode(r io.Reader, v any) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	*v.(*string) = strings.ToUpper(string(b))
+	return nil
+}
+
+func TestRegisterEncoderDecoder(t *testing.T) {
+	const contentType = "application/x-test-upper"
+	RegisterEncoder(contentType, upperCodec{})
+	RegisterDecoder(contentType, upperCodec{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "PAYLOAD" {
+			t.Errorf("server saw %q, want PAYLOAD (encoded via the registered codec)", body)
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Write([]byte("echoed"))
+	}))
+	defer srv.Close()
+
+	c, err := New(Config{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	var out string
+	opt := Options{ContentType: contentType}
+	if _, err := c.Request(context.Background(), http.MethodPost, srv.URL, "payload", &out, opt); err != nil {
+		t.Fatalf("Request: %v", err)
+	}
+	if out != "ECHOED" {
+		t.Fatalf("got %q, want ECHOED (decoded via the registered codec)", out)
+	}
+}
@@ -0,0 +1,338 @@
+package httpclient
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// cookieRecord is the serializable form of one stored cookie, keyed to
+// the scheme+host it was set for since net/http/cookiejar doesn't
+// expose its own domain index.
+type cookieRecord struct {
+	Scheme string       `json:"scheme"`
+	Host   string       `json:"host"`
+	Cookie *http.Cookie `json:"cookie"`
+}
+
+// SaveCookies writes every cookie held for a host this Client has
+// visited (via Do, SetCookie, or LoadCookies) to path as JSON.
+func (c *Client) SaveCookies(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return json.NewEncoder(f).Encode(c.exportCookies())
+}
+
+// LoadCookies reads a file written by SaveCookies and merges its
+// cookies into the jar.
+func (c *Client) LoadCookies(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var records []cookieRecord
+	if err := json.NewDecoder(f).Decode(&records); err != nil {
+		return err
+	}
+	c.importCookies(records)
+	return nil
+}
+
+// SaveCookiesNetscape writes the jar out in the Netscape cookies.txt
+// format used by curl and wget.
+func (c *Client) SaveCookiesNetscape(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fmt.Fprintln(f, "# Netscape HTTP Cookie File")
+	for _, r := range c.exportCookies() {
+		ck := r.Cookie
+		prefix := ""
+		if ck.HttpOnly {
+			prefix = "#HttpOnly_"
+		}
+		path := ck.Path
+		if path == "" {
+			path = "/"
+		}
+		secure := "FALSE"
+		if ck.Secure {
+			secure = "TRUE"
+		}
+		var expires int64
+		if !ck.Expires.IsZero() {
+			expires = ck.Expires.Unix()
+		}
+		fmt.Fprintf(f, "%s%s\tFALSE\t%s\t%s\t%d\t%s\t%s\n", prefix, r.Host, path, secure, expires, ck.Name, ck.Value)
+	}
+	return nil
+}
+
+// LoadCookiesNetscape reads a Netscape cookies.txt file (as produced by
+// curl/wget or SaveCookiesNetscape) and merges its cookies into the
+// jar.
+func (c *Client) LoadCookiesNetscape(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var records []cookieRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		httpOnly := false
+		if strings.HasPrefix(line, "#HttpOnly_") {
+			httpOnly = true
+			line = strings.TrimPrefix(line, "#HttpOnly_")
+		} else if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			continue
+		}
+
+		host := fields[0]
+		cookiePath := fields[2]
+		secure := fields[3] == "TRUE"
+		expiresUnix, _ := strconv.ParseInt(fields[4], 10, 64)
+
+		var expires time.Time
+		if expiresUnix > 0 {
+			expires = time.Unix(expiresUnix, 0)
+		}
+
+		records = append(records, cookieRecord{
+			Scheme: schemeFor(secure),
+			Host:   host,
+			Cookie: &http.Cookie{
+				Name:     fields[5],
+				Value:    fields[6],
+				Path:     cookiePath,
+				Secure:   secure,
+				HttpOnly: httpOnly,
+				Expires:  expires,
+			},
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	c.importCookies(records)
+	return nil
+}
+
+// ErrNoDecryptableCookies is returned by ImportFromBrowser when a
+// Chrome cookie store was read successfully but every row's value was
+// empty, meaning the cookies exist but are encrypted with an
+// OS-managed key this package can't access (the case since Chrome 80).
+// Callers can use this to tell "the profile has no cookies" apart from
+// "the profile's cookies aren't readable here".
+var ErrNoDecryptableCookies = errors.New("httpclient: chrome cookie store has no decryptable cookies")
+
+// ImportFromBrowser reads cookies out of a Chrome or Firefox cookie
+// store (profile may be the sqlite file itself or its containing
+// profile directory) and merges readable cookies into the jar,
+// returning the number imported. Firefox stores cookie values in the
+// clear, so those import fully. Chrome encrypts its Value column with
+// an OS-managed key that this package doesn't have access to, so only
+// legacy plaintext Chrome cookies (if any) come through; if the store
+// has rows but none are decryptable, it returns (0,
+// ErrNoDecryptableCookies).
+func (c *Client) ImportFromBrowser(profile string) (int, error) {
+	dbPath, err := locateCookieDB(profile)
+	if err != nil {
+		return 0, err
+	}
+
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?mode=ro&immutable=1")
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	records, err := readFirefoxCookies(db)
+	if err != nil {
+		var skipped int
+		records, skipped, err = readChromeCookies(db)
+		if err == nil && len(records) == 0 && skipped > 0 {
+			return 0, ErrNoDecryptableCookies
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	c.importCookies(records)
+	return len(records), nil
+}
+
+func locateCookieDB(profile string) (string, error) {
+	info, err := os.Stat(profile)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return profile, nil
+	}
+	for _, name := range []string{"cookies.sqlite", "Cookies"} {
+		candidate := filepath.Join(profile, name)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("httpclient: no cookie database found under %s", profile)
+}
+
+func readFirefoxCookies(db *sql.DB) ([]cookieRecord, error) {
+	rows, err := db.Query(`SELECT host, path, isSecure, isHttpOnly, expiry, name, value FROM moz_cookies`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []cookieRecord
+	for rows.Next() {
+		var host, path, name, value string
+		var secure, httpOnly int
+		var expiry int64
+		if err := rows.Scan(&host, &path, &secure, &httpOnly, &expiry, &name, &value); err != nil {
+			return nil, err
+		}
+		var expires time.Time
+		if expiry != 0 {
+			expires = time.Unix(expiry, 0)
+		}
+		records = append(records, cookieRecord{
+			Scheme: schemeFor(secure != 0),
+			Host:   strings.TrimPrefix(host, "."),
+			Cookie: &http.Cookie{
+				Name: name, Value: value, Path: path,
+				Secure: secure != 0, HttpOnly: httpOnly != 0,
+				Expires: expires,
+			},
+		})
+	}
+	return records, rows.Err()
+}
+
+// readChromeCookies returns the decryptable cookies plus a count of
+// rows skipped because their value column was empty (the
+// encrypted_value-only case described on ImportFromBrowser), so the
+// caller can tell "no cookies" from "nothing decryptable".
+func readChromeCookies(db *sql.DB) ([]cookieRecord, int, error) {
+	rows, err := db.Query(`SELECT host_key, path, is_secure, is_httponly, expires_utc, name, value FROM cookies`)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var records []cookieRecord
+	var skipped int
+	for rows.Next() {
+		var host, path, name, value string
+		var secure, httpOnly int
+		var expiresUTC int64
+		if err := rows.Scan(&host, &path, &secure, &httpOnly, &expiresUTC, &name, &value); err != nil {
+			return nil, 0, err
+		}
+		if value == "" {
+			// encrypted_value-only row; we don't have the OS keychain
+			// key needed to decrypt it here.
+			skipped++
+			continue
+		}
+		records = append(records, cookieRecord{
+			Scheme: schemeFor(secure != 0),
+			Host:   strings.TrimPrefix(host, "."),
+			Cookie: &http.Cookie{
+				Name: name, Value: value, Path: path,
+				Secure: secure != 0, HttpOnly: httpOnly != 0,
+				Expires: chromeEpochToTime(expiresUTC),
+			},
+		})
+	}
+	return records, skipped, rows.Err()
+}
+
+func schemeFor(secure bool) string {
+	if secure {
+		return "https"
+	}
+	return "http"
+}
+
+// chromeEpochToTime converts Chrome's microseconds-since-1601-01-01
+// timestamp to a time.Time.
+func chromeEpochToTime(v int64) time.Time {
+	if v == 0 {
+		return time.Time{}
+	}
+	const chromeToUnixMicros = 11644473600 * 1000000
+	return time.UnixMicro(v - chromeToUnixMicros)
+}
+
+func (c *Client) exportCookies() []cookieRecord {
+	c.cookieHostsMu.Lock()
+	keys := make([]string, 0, len(c.cookieHosts))
+	for key := range c.cookieHosts {
+		keys = append(keys, key)
+	}
+	c.cookieHostsMu.Unlock()
+
+	var records []cookieRecord
+	for _, key := range keys {
+		u, err := url.Parse(key)
+		if err != nil {
+			continue
+		}
+		for _, ck := range c.http.Jar.Cookies(u) {
+			records = append(records, cookieRecord{Scheme: u.Scheme, Host: u.Host, Cookie: ck})
+		}
+	}
+	return records
+}
+
+func (c *Client) importCookies(records []cookieRecord) {
+	byURL := map[string][]*http.Cookie{}
+	urls := map[string]*url.URL{}
+	for _, r := range records {
+		u := &url.URL{Scheme: r.Scheme, Host: r.Host}
+		key := u.String()
+		byURL[key] = append(byURL[key], r.Cookie)
+		urls[key] = u
+	}
+	for key, cookies := range byURL {
+		u := urls[key]
+		c.http.Jar.SetCookies(u, cookies)
+		c.recordVisited(u)
+	}
+}
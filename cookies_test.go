@@ -0,0 +1,98 @@
+package httpclient
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+// TestCookiesSaveLoadRoundTrip verifies SaveCookies/LoadCookies preserve
+// cookies set across two distinct (PSL-scoped) hosts into a fresh
+// Client's jar.
+func TestCookiesSaveLoadRoundTrip(t *testing.T) {
+	src, err := New(Config{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if err := src.SetCookie("https://example.com/", "session", "abc123"); err != nil {
+		t.Fatalf("SetCookie: %v", err)
+	}
+	// example.co.uk's public suffix is co.uk, not .com, so this must be
+	// tracked as a distinct PSL-scoped domain from example.com above.
+	if err := src.SetCookie("https://shop.example.co.uk/", "cart", "xyz789"); err != nil {
+		t.Fatalf("SetCookie: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "cookies.json")
+	if err := src.SaveCookies(path); err != nil {
+		t.Fatalf("SaveCookies: %v", err)
+	}
+
+	dst, err := New(Config{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := dst.LoadCookies(path); err != nil {
+		t.Fatalf("LoadCookies: %v", err)
+	}
+
+	cks, err := dst.GetCookies("https://example.com/")
+	if err != nil {
+		t.Fatalf("GetCookies: %v", err)
+	}
+	if !hasCookie(cks, "session", "abc123") {
+		t.Fatalf("example.com cookies = %v, want session=abc123", cks)
+	}
+
+	cks, err = dst.GetCookies("https://shop.example.co.uk/")
+	if err != nil {
+		t.Fatalf("GetCookies: %v", err)
+	}
+	if !hasCookie(cks, "cart", "xyz789") {
+		t.Fatalf("shop.example.co.uk cookies = %v, want cart=xyz789", cks)
+	}
+}
+
+// TestCookiesNetscapeRoundTrip verifies SaveCookiesNetscape/
+// LoadCookiesNetscape round-trip through the curl/wget cookies.txt
+// format.
+func TestCookiesNetscapeRoundTrip(t *testing.T) {
+	src, err := New(Config{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := src.SetCookie("https://example.com/", "session", "abc123"); err != nil {
+		t.Fatalf("SetCookie: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "cookies.txt")
+	if err := src.SaveCookiesNetscape(path); err != nil {
+		t.Fatalf("SaveCookiesNetscape: %v", err)
+	}
+
+	dst, err := New(Config{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := dst.LoadCookiesNetscape(path); err != nil {
+		t.Fatalf("LoadCookiesNetscape: %v", err)
+	}
+
+	cks, err := dst.GetCookies("https://example.com/")
+	if err != nil {
+		t.Fatalf("GetCookies: %v", err)
+	}
+	if !hasCookie(cks, "session", "abc123") {
+		t.Fatalf("cookies = %v, want session=abc123", cks)
+	}
+}
+
+func hasCookie(cks []*http.Cookie, name, value string) bool {
+	for _, ck := range cks {
+		if ck.Name == name && ck.Value == value {
+			return true
+		}
+	}
+	return false
+}
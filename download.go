@@ -0,0 +1,257 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// ErrRangeNotSupported is returned by Download when Resume was
+// requested and the server's 206 response has no parseable
+// Content-Range header, or one whose first byte is not the offset
+// that was asked for.
+var ErrRangeNotSupported = errors.New("httpclient: server does not support range requests")
+
+// DownloadOptions configures Client.Download.
+type DownloadOptions struct {
+	Options
+
+	// Progress, if set, is invoked periodically as bytes are written
+	// to dst, and once more with the final total when the download
+	// completes.
+	Progress func(bytesRead, totalBytes int64)
+	// Resume, when true and dst is an *os.File (or a ResumableFile)
+	// with existing content, sends a Range request to continue a
+	// partial download instead of starting over.
+	Resume bool
+	// ChunkSize controls how often Progress fires and the buffer size
+	// used to copy the response body. Defaults to 32KiB.
+	ChunkSize int
+}
+
+// ResumableFile adapts a sink that isn't an *os.File for use as
+// Download's dst with Resume set, by reporting how many bytes it
+// already holds.
+type ResumableFile struct {
+	io.Writer
+	Existing int64
+}
+
+// Download streams rawURL's body into dst, returning the number of
+// bytes newly written. When opt.Resume is set and dst already has
+// content, it asks the server to continue from that offset via a Range
+// header; a 200 OK response (server ignored the range) falls back to a
+// full download when dst is an *os.File, which is truncated first.
+func (c *Client) Download(ctx context.Context, rawURL string, dst io.Writer, opt DownloadOptions) (int64, error) {
+	if opt.ChunkSize <= 0 {
+		opt.ChunkSize = 32 * 1024
+	}
+
+	var offset int64
+	if opt.Resume {
+		switch d := dst.(type) {
+		case *os.File:
+			info, err := d.Stat()
+			if err != nil {
+				return 0, err
+			}
+			offset = info.Size()
+		case ResumableFile:
+			offset = d.Existing
+		}
+	}
+
+	reqOpt := opt.Options
+	if reqOpt.Headers == nil {
+		reqOpt.Headers = map[string]string{}
+	}
+	// Download streams resp.Body straight to dst with no decompression,
+	// so it must not let the client's default Accept-Encoding (see New)
+	// invite a compressed response: a gzip/deflate/br body would land on
+	// disk unmodified instead of the actual file content. A caller who
+	// explicitly set Accept-Encoding in opt.Headers is left alone.
+	if _, ok := reqOpt.Headers["Accept-Encoding"]; !ok {
+		reqOpt.Headers["Accept-Encoding"] = "identity"
+	}
+	if offset > 0 {
+		reqOpt.Headers["Range"] = fmt.Sprintf("bytes=%d-", offset)
+	}
+
+	// Use doRaw directly rather than Get/Do: Download streams the body
+	// itself and must not have it eagerly buffered into a *Response.
+	resp, _, err := c.doRaw(ctx, http.MethodGet, rawURL, nil, reqOpt)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if offset > 0 {
+		switch resp.StatusCode {
+		case http.StatusPartialContent:
+			var start int64
+			if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-", &start); err != nil || start != offset {
+				return 0, ErrRangeNotSupported
+			}
+			if f, ok := dst.(*os.File); ok {
+				if _, err := f.Seek(offset, io.SeekStart); err != nil {
+					return 0, err
+				}
+			}
+		case http.StatusOK:
+			if f, ok := dst.(*os.File); ok {
+				if err := f.Truncate(0); err != nil {
+					return 0, err
+				}
+				if _, err := f.Seek(0, io.SeekStart); err != nil {
+					return 0, err
+				}
+			}
+			offset = 0
+		default:
+			return 0, fmt.Errorf("httpclient: download failed with status %s", resp.Status)
+		}
+	} else if resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("httpclient: download failed with status %s", resp.Status)
+	}
+
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+
+	var reader io.Reader = resp.Body
+	var pw *progressWriter
+	if opt.Progress != nil {
+		pw = &progressWriter{onProgress: opt.Progress, total: total, read: offset, every: int64(opt.ChunkSize)}
+		reader = io.TeeReader(resp.Body, pw)
+	}
+
+	n, err := io.CopyBuffer(dst, reader, make([]byte, opt.ChunkSize))
+	if err == nil && pw != nil {
+		pw.onProgress(pw.read, pw.total)
+	}
+	return n, err
+}
+
+// progressWriter is the TeeReader sink used by Download and Upload to
+// fire onProgress at most once per `every` bytes.
+type progressWriter struct {
+	onProgress func(read, total int64)
+	total      int64
+	read       int64
+	every      int64
+	sinceLast  int64
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	w.read += int64(n)
+	w.sinceLast += int64(n)
+	if w.sinceLast >= w.every {
+		w.sinceLast = 0
+		w.onProgress(w.read, w.total)
+	}
+	return n, nil
+}
+
+// UploadOptions configures Client.Upload.
+type UploadOptions struct {
+	Options
+
+	// FieldName is the multipart field the file is attached under.
+	// Defaults to "file".
+	FieldName string
+	// FileName overrides the filename sent in the multipart headers.
+	// Defaults to filepath.Base(path).
+	FileName string
+	// Progress, if set, is invoked periodically as bytes are read from
+	// the file.
+	Progress func(bytesSent, totalBytes int64)
+}
+
+// Upload streams the file at path to rawURL as a multipart/form-data
+// request body, invoking opt.Progress as it reads, mirroring Download's
+// progress hook.
+//
+// The body is supplied via Options.GetBody rather than as a single
+// io.Reader: doRaw's own replay-buffering (needed when Config.MaxRetries
+// is set) would otherwise read the whole pipe into memory up front
+// before the request is even sent, defeating the point of streaming and
+// firing Progress to completion before a byte reaches the server. With
+// GetBody set, each attempt reopens the file and re-streams it fresh.
+func (c *Client) Upload(ctx context.Context, rawURL, path string, opt UploadOptions) (*Response, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fieldName := opt.FieldName
+	if fieldName == "" {
+		fieldName = "file"
+	}
+	fileName := opt.FileName
+	if fileName == "" {
+		fileName = filepath.Base(path)
+	}
+
+	// The multipart boundary must be the same across every attempt (it's
+	// embedded in the Content-Type header set once below), so it's
+	// generated up front and threaded into each attempt's writer via
+	// SetBoundary instead of letting multipart.NewWriter pick a fresh
+	// random one per GetBody call.
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+
+	getBody := func() (io.ReadCloser, error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+
+		pr, pipeW := io.Pipe()
+		mw := multipart.NewWriter(pipeW)
+		if err := mw.SetBoundary(boundary); err != nil {
+			f.Close()
+			return nil, err
+		}
+
+		go func() {
+			defer f.Close()
+
+			part, err := mw.CreateFormFile(fieldName, fileName)
+			if err != nil {
+				pipeW.CloseWithError(err)
+				return
+			}
+
+			var reader io.Reader = f
+			if opt.Progress != nil {
+				reader = io.TeeReader(f, &progressWriter{onProgress: opt.Progress, total: info.Size(), every: 32 * 1024})
+			}
+
+			if _, err := io.Copy(part, reader); err != nil {
+				pipeW.CloseWithError(err)
+				return
+			}
+			pipeW.CloseWithError(mw.Close())
+		}()
+
+		return pr, nil
+	}
+
+	reqOpt := opt.Options
+	reqOpt.GetBody = getBody
+	if reqOpt.Headers == nil {
+		reqOpt.Headers = map[string]string{}
+	}
+	reqOpt.Headers["Content-Type"] = "multipart/form-data; boundary=" + boundary
+
+	// The non-nil http.NoBody placeholder only satisfies prepareBody's
+	// "is there a body at all" check; every attempt, including the
+	// first, actually gets its body from GetBody above.
+	return c.Do(ctx, http.MethodPost, rawURL, http.NoBody, reqOpt)
+}
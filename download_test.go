@@ -0,0 +1,116 @@
+package httpclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestUploadRetriesReadFreshEachAttempt verifies Upload's GetBody
+// reopens and re-streams the file per attempt, so a retry doesn't send a
+// truncated or empty body the second time around.
+func TestUploadRetriesReadFreshEachAttempt(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "upload-*.txt")
+	if err != nil {
+		t.Fatalf("CreateTemp: %v", err)
+	}
+	const content = "payload bytes that must survive a retry"
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatalf("WriteString: %v", err)
+	}
+	f.Close()
+
+	var attempts int32
+	var gotBodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("ParseMultipartForm: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mf, _, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("FormFile: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		buf := make([]byte, 1024)
+		n, _ := mf.Read(buf)
+		gotBodies = append(gotBodies, string(buf[:n]))
+
+		if atomic.AddInt32(&attempts, 1) < 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c, err := New(Config{
+		MaxRetries:     3,
+		RetryBaseDelay: time.Millisecond,
+		RetryMaxDelay:  5 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	resp, err := c.Upload(context.Background(), srv.URL, f.Name(), UploadOptions{})
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected eventual 200, got %d", resp.StatusCode)
+	}
+	if len(gotBodies) != 2 {
+		t.Fatalf("expected 2 attempts to reach the server, got %d", len(gotBodies))
+	}
+	for i, got := range gotBodies {
+		if got != content {
+			t.Fatalf("attempt %d: got body %q, want %q", i, got, content)
+		}
+	}
+}
+
+// TestDownloadRejectsCompression verifies Download asks the server not
+// to compress the response (since it streams resp.Body straight to dst
+// with no decompression) even though the client's default
+// Accept-Encoding would otherwise invite a compressed body that a naive
+// server would happily send.
+func TestDownloadRejectsCompression(t *testing.T) {
+	const want = "the quick brown fox jumps over the lazy dog"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ae := r.Header.Get("Accept-Encoding"); ae != "" && ae != "identity" {
+			var buf bytes.Buffer
+			gz := gzip.NewWriter(&buf)
+			gz.Write([]byte(want))
+			gz.Close()
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Write(buf.Bytes())
+			return
+		}
+		w.Write([]byte(want))
+	}))
+	defer srv.Close()
+
+	c, err := New(Config{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	var dst bytes.Buffer
+	n, err := c.Download(context.Background(), srv.URL, &dst, DownloadOptions{})
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if got := dst.String(); got != want {
+		t.Fatalf("Download wrote %q (%d bytes), want %q", got, n, want)
+	}
+}
@@ -0,0 +1,300 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ErrAllProxiesCoolingDown is returned by ProxyPool.Proxy (and
+// therefore surfaces as the request's Transport error) when every
+// proxy in the pool is currently cooling down.
+var ErrAllProxiesCoolingDown = errors.New("httpclient: all proxies are cooling down")
+
+// ProxyPolicy selects how ProxyPool picks a proxy for each request.
+type ProxyPolicy int
+
+const (
+	RoundRobin ProxyPolicy = iota
+	Random
+	StickyPerHost
+)
+
+// proxyState is the health record for a single proxy in the pool.
+type proxyState struct {
+	url *url.URL
+
+	mu           sync.Mutex
+	successes    int
+	failures     int
+	totalLatency time.Duration
+	coolingUntil time.Time
+}
+
+func (p *proxyState) healthy() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return time.Now().After(p.coolingUntil)
+}
+
+func (p *proxyState) recordSuccess(latency time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.successes++
+	p.totalLatency += latency
+	p.failures = 0
+	p.coolingUntil = time.Time{}
+}
+
+func (p *proxyState) recordFailure(coolDown time.Duration, threshold int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failures++
+	if p.failures >= threshold {
+		p.coolingUntil = time.Now().Add(coolDown)
+	}
+}
+
+// ProxyPool rotates among a set of proxies per Policy, tracking
+// per-proxy success/failure counts and latency so a proxy that keeps
+// failing is put into a cool-down window instead of being retried on
+// every request.
+type ProxyPool struct {
+	Policy ProxyPolicy
+	// FailureThreshold is how many consecutive failures put a proxy
+	// into cool-down. Defaults to 3.
+	FailureThreshold int
+	// CoolDown is how long a failing proxy is skipped for. Defaults to
+	// 30s.
+	CoolDown time.Duration
+
+	mu      sync.Mutex
+	proxies []*proxyState
+	next    uint64
+
+	stickyMu sync.Mutex
+	sticky   map[string]*proxyState
+}
+
+// proxyPoolCtxKey is the context key doRaw uses to hand Proxy a place to
+// record which proxy it picked, so report can read it back after the
+// round trip. Keying off the context (rather than the *http.Request,
+// which net/http replaces with a new value per redirect hop while
+// keeping the same context) means a redirected request leaves exactly
+// one selection behind instead of one per hop.
+type proxyPoolCtxKey struct{}
+
+// proxySelection is the mutable holder stashed in the context: Proxy may
+// be called multiple times for the same context (once per redirect hop)
+// and each call overwrites ps with the proxy used for that hop.
+type proxySelection struct {
+	mu sync.Mutex
+	ps *proxyState
+}
+
+// withProxySelection returns a context that Proxy/report use to pass the
+// picked proxy from Transport.Proxy to the post-request bookkeeping in
+// doRaw.
+func withProxySelection(ctx context.Context) context.Context {
+	return context.WithValue(ctx, proxyPoolCtxKey{}, &proxySelection{})
+}
+
+// NewProxyPool builds a pool from a mix of host:port:user:pw and
+// URL-form entries (each parsed with ProxyURLConvert, so SOCKS5 URLs
+// are accepted as-is).
+func NewProxyPool(sources []string) (*ProxyPool, error) {
+	if len(sources) == 0 {
+		return nil, errors.New("httpclient: proxy pool needs at least one source")
+	}
+
+	pool := &ProxyPool{
+		FailureThreshold: 3,
+		CoolDown:         30 * time.Second,
+		sticky:           map[string]*proxyState{},
+	}
+
+	for _, src := range sources {
+		raw, err := ProxyURLConvert(src)
+		if err != nil {
+			return nil, err
+		}
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, err
+		}
+		pool.proxies = append(pool.proxies, &proxyState{url: u})
+	}
+
+	return pool, nil
+}
+
+func (p *ProxyPool) failureThreshold() int {
+	if p.FailureThreshold <= 0 {
+		return 3
+	}
+	return p.FailureThreshold
+}
+
+// pick returns the next healthy proxy starting from startIdx(n), or
+// from the round-robin cursor when startIdx is nil, skipping any proxy
+// currently cooling down.
+func (p *ProxyPool) pick(startIdx func(n int) int) *proxyState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n := len(p.proxies)
+	if n == 0 {
+		return nil
+	}
+
+	var start int
+	if startIdx != nil {
+		start = startIdx(n)
+	} else {
+		start = int(p.next % uint64(n))
+		p.next++
+	}
+
+	for i := 0; i < n; i++ {
+		ps := p.proxies[(start+i)%n]
+		if ps.healthy() {
+			return ps
+		}
+	}
+	return nil
+}
+
+func (p *ProxyPool) stickyState(host string) *proxyState {
+	p.stickyMu.Lock()
+	ps, ok := p.sticky[host]
+	p.stickyMu.Unlock()
+	if ok && ps.healthy() {
+		return ps
+	}
+
+	picked := p.pick(nil)
+	if picked == nil {
+		return nil
+	}
+
+	p.stickyMu.Lock()
+	p.sticky[host] = picked
+	p.stickyMu.Unlock()
+	return picked
+}
+
+func (p *ProxyPool) stateFor(req *http.Request) *proxyState {
+	switch p.Policy {
+	case StickyPerHost:
+		return p.stickyState(req.URL.Host)
+	case Random:
+		return p.pick(func(n int) int { return rand.Intn(n) })
+	default:
+		return p.pick(nil)
+	}
+}
+
+// Proxy implements the http.Transport.Proxy signature. Wire it in via
+// Config.ProxyPool, which New does automatically. For a redirected
+// request, net/http calls this once per hop with a cloned *http.Request
+// that shares the original's context, so the selection is recorded
+// against the context rather than the request value.
+func (p *ProxyPool) Proxy(req *http.Request) (*url.URL, error) {
+	ps := p.stateFor(req)
+	if ps == nil {
+		return nil, ErrAllProxiesCoolingDown
+	}
+	if sel, ok := req.Context().Value(proxyPoolCtxKey{}).(*proxySelection); ok {
+		sel.mu.Lock()
+		sel.ps = ps
+		sel.mu.Unlock()
+	}
+	return ps.url, nil
+}
+
+// report records the outcome of the request that Transport.Proxy was
+// asked to pick a proxy for, reading the selection back off ctx (see
+// withProxySelection). Client.Do calls this once per attempt when
+// Config.ProxyPool is set.
+func (p *ProxyPool) report(ctx context.Context, latency time.Duration, err error) {
+	sel, ok := ctx.Value(proxyPoolCtxKey{}).(*proxySelection)
+	if !ok {
+		return
+	}
+	sel.mu.Lock()
+	ps := sel.ps
+	sel.mu.Unlock()
+	if ps == nil {
+		return
+	}
+	if err != nil {
+		ps.recordFailure(p.CoolDown, p.failureThreshold())
+	} else {
+		ps.recordSuccess(latency)
+	}
+}
+
+// Healthy returns the proxies (credentials redacted) that are not
+// currently cooling down.
+func (p *ProxyPool) Healthy() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var out []string
+	for _, ps := range p.proxies {
+		if ps.healthy() {
+			out = append(out, redactProxyURL(ps.url))
+		}
+	}
+	return out
+}
+
+func redactProxyURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	return redacted.String()
+}
+
+// Validate concurrently probes every proxy in the pool against
+// probeURL and records the result as a success or failure. A failed
+// probe counts toward FailureThreshold like any other failure, so
+// Healthy drops a proxy only once it reaches that threshold.
+func (p *ProxyPool) Validate(ctx context.Context, probeURL string) error {
+	p.mu.Lock()
+	states := append([]*proxyState(nil), p.proxies...)
+	p.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, ps := range states {
+		wg.Add(1)
+		go func(ps *proxyState) {
+			defer wg.Done()
+
+			client := &http.Client{
+				Transport: &http.Transport{Proxy: http.ProxyURL(ps.url)},
+				Timeout:   10 * time.Second,
+			}
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, probeURL, nil)
+			if err != nil {
+				ps.recordFailure(p.CoolDown, p.failureThreshold())
+				return
+			}
+
+			start := time.Now()
+			resp, err := client.Do(req)
+			if err != nil {
+				ps.recordFailure(p.CoolDown, p.failureThreshold())
+				return
+			}
+			resp.Body.Close()
+			ps.recordSuccess(time.Since(start))
+		}(ps)
+	}
+	wg.Wait()
+	return nil
+}
@@ -0,0 +1,167 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+)
+
+func newTestRequest(t *testing.T, rawURL string, ctx context.Context) *http.Request {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		t.Fatalf("NewRequestWithContext: %v", err)
+	}
+	return req
+}
+
+// pickHost drives pool.Proxy/report the same way doRaw does for one
+// simulated attempt, calling them directly with no transport (no real
+// dialing), and returns the proxy host it picked.
+func pickHost(t *testing.T, pool *ProxyPool, rawURL string, fail bool) string {
+	t.Helper()
+	ctx := withProxySelection(context.Background())
+	req := newTestRequest(t, rawURL, ctx)
+
+	u, err := pool.Proxy(req)
+	if err != nil {
+		t.Fatalf("Proxy: %v", err)
+	}
+
+	var reportErr error
+	if fail {
+		reportErr = errors.New("simulated failure")
+	}
+	pool.report(ctx, time.Millisecond, reportErr)
+	return u.Host
+}
+
+func TestProxyPoolRoundRobin(t *testing.T) {
+	pool, err := NewProxyPool([]string{
+		"http://proxy1.test:8080",
+		"http://proxy2.test:8080",
+		"http://proxy3.test:8080",
+	})
+	if err != nil {
+		t.Fatalf("NewProxyPool: %v", err)
+	}
+	pool.Policy = RoundRobin
+
+	var got []string
+	for i := 0; i < 6; i++ {
+		got = append(got, pickHost(t, pool, "http://example.com/", false))
+	}
+	want := []string{
+		"proxy1.test:8080", "proxy2.test:8080", "proxy3.test:8080",
+		"proxy1.test:8080", "proxy2.test:8080", "proxy3.test:8080",
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("pick %d = %q, want %q (full sequence %v)", i, got[i], want[i], got)
+		}
+	}
+}
+
+func TestProxyPoolStickyPerHost(t *testing.T) {
+	pool, err := NewProxyPool([]string{
+		"http://proxy1.test:8080",
+		"http://proxy2.test:8080",
+		"http://proxy3.test:8080",
+	})
+	if err != nil {
+		t.Fatalf("NewProxyPool: %v", err)
+	}
+	pool.Policy = StickyPerHost
+
+	first := pickHost(t, pool, "http://a.example.com/", false)
+	for i := 0; i < 4; i++ {
+		if got := pickHost(t, pool, "http://a.example.com/", false); got != first {
+			t.Fatalf("sticky pick %d = %q, want the same proxy every time (%q)", i, got, first)
+		}
+	}
+
+	// A different host may land on a different proxy, but is itself
+	// sticky across repeats.
+	otherFirst := pickHost(t, pool, "http://b.example.com/", false)
+	for i := 0; i < 4; i++ {
+		if got := pickHost(t, pool, "http://b.example.com/", false); got != otherFirst {
+			t.Fatalf("sticky pick for b.example.com %d = %q, want %q", i, got, otherFirst)
+		}
+	}
+}
+
+func TestProxyPoolCoolDown(t *testing.T) {
+	pool, err := NewProxyPool([]string{
+		"http://proxy1.test:8080",
+		"http://proxy2.test:8080",
+	})
+	if err != nil {
+		t.Fatalf("NewProxyPool: %v", err)
+	}
+	pool.Policy = RoundRobin
+	pool.FailureThreshold = 2
+	pool.CoolDown = 20 * time.Millisecond
+
+	// Drive proxy1 to its failure threshold without touching proxy2, by
+	// always picking proxy1 directly rather than round-robining.
+	var proxy1 *proxyState
+	pool.mu.Lock()
+	for _, ps := range pool.proxies {
+		if ps.url.Host == "proxy1.test:8080" {
+			proxy1 = ps
+		}
+	}
+	pool.mu.Unlock()
+	if proxy1 == nil {
+		t.Fatal("could not find proxy1 in pool")
+	}
+	proxy1.recordFailure(pool.CoolDown, pool.failureThreshold())
+	proxy1.recordFailure(pool.CoolDown, pool.failureThreshold())
+
+	if proxy1.healthy() {
+		t.Fatal("expected proxy1 to be cooling down after hitting the failure threshold")
+	}
+
+	healthy := pool.Healthy()
+	sort.Strings(healthy)
+	if len(healthy) != 1 || healthy[0] != "http://proxy2.test:8080" {
+		t.Fatalf("Healthy() = %v, want only proxy2", healthy)
+	}
+
+	// Every pick while proxy1 cools down must land on proxy2.
+	for i := 0; i < 3; i++ {
+		if got := pickHost(t, pool, "http://example.com/", false); got != "proxy2.test:8080" {
+			t.Fatalf("pick %d = %q while proxy1 cools down, want proxy2.test:8080", i, got)
+		}
+	}
+
+	time.Sleep(pool.CoolDown + 10*time.Millisecond)
+	if !proxy1.healthy() {
+		t.Fatal("expected proxy1 to recover once CoolDown elapsed")
+	}
+}
+
+func TestProxyPoolAllCoolingDown(t *testing.T) {
+	pool, err := NewProxyPool([]string{"http://proxy1.test:8080"})
+	if err != nil {
+		t.Fatalf("NewProxyPool: %v", err)
+	}
+	pool.FailureThreshold = 1
+	pool.CoolDown = time.Minute
+
+	ctx := withProxySelection(context.Background())
+	req := newTestRequest(t, "http://example.com/", ctx)
+	if _, err := pool.Proxy(req); err != nil {
+		t.Fatalf("Proxy: %v", err)
+	}
+	pool.report(ctx, time.Millisecond, errors.New("fail"))
+
+	ctx2 := withProxySelection(context.Background())
+	req2 := newTestRequest(t, "http://example.com/", ctx2)
+	if _, err := pool.Proxy(req2); !errors.Is(err, ErrAllProxiesCoolingDown) {
+		t.Fatalf("Proxy error = %v, want ErrAllProxiesCoolingDown", err)
+	}
+}
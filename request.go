@@ -3,80 +3,264 @@ package httpclient
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"io"
 	"net/http"
+	"net/http/httputil"
 	"net/url"
+	"time"
 )
 
 type Options struct {
 	Headers map[string]string
 	Query   map[string]string
 	Cookies []*http.Cookie
+	// GetBody lets a request with a non-nil body be retried: it is
+	// called again for every attempt to get a fresh copy of the body.
+	// If nil and retries are enabled, Do buffers body in memory itself.
+	GetBody func() (io.ReadCloser, error)
+
+	// ContentType picks the Encoder Request uses to serialize its in
+	// argument. Defaults to application/json.
+	ContentType string
+	// Accept picks the Decoder Request uses for the response when the
+	// server's own Content-Type header is missing.
+	Accept string
 }
 
-func (c *Client) Do(ctx context.Context, method, rawURL string, body io.Reader, opt Options) (*http.Response, error) {
-	finalURL, err := applyQuery(rawURL, opt.Query)
+// Do sends the request and wraps the result in a *Response, decoding
+// Content-Encoding and applying Config.MaxResponseBytes. See doRaw for
+// the retry/circuit-breaker mechanics.
+func (c *Client) Do(ctx context.Context, method, rawURL string, body io.Reader, opt Options) (*Response, error) {
+	resp, debugReq, err := c.doRaw(ctx, method, rawURL, body, opt)
 	if err != nil {
 		return nil, err
 	}
+	return c.wrapResponse(resp, debugReq)
+}
 
-	req, err := http.NewRequestWithContext(ctx, method, finalURL, body)
+// doRaw sends the request, retrying on transient network errors or a
+// response status in Config.RetryableStatuses according to the
+// client's retry policy, and consulting the per-host circuit breaker
+// (if configured) before each attempt. It returns the raw, unwrapped
+// *http.Response (body not yet read) plus the dumped wire request when
+// Config.Debug is set, so Download/Upload can stream the body without
+// Do's eager buffering.
+func (c *Client) doRaw(ctx context.Context, method, rawURL string, body io.Reader, opt Options) (*http.Response, []byte, error) {
+	finalURL, err := applyQuery(rawURL, opt.Query)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	// base headers
-	for k, v := range c.baseHeaders {
-		req.Header.Set(k, v)
+	getBody, staticBody, err := prepareBody(body, opt, c.retryCfg.maxRetries > 0)
+	if err != nil {
+		return nil, nil, err
 	}
-	// per-request headers
-	for k, v := range opt.Headers {
-		req.Header.Set(k, v)
+
+	var breaker *circuitBreaker
+	if u, err := url.Parse(finalURL); err == nil {
+		c.recordVisited(u)
+		if c.breakers != nil {
+			breaker = c.breakers.forHost(u.Host)
+		}
 	}
-	// cookies (optional)
-	for _, ck := range opt.Cookies {
-		req.AddCookie(ck)
+
+	if c.proxyPool != nil {
+		ctx = withProxySelection(ctx)
 	}
 
-	return c.http.Do(req)
+	for attempt := 0; ; attempt++ {
+		if breaker != nil && !breaker.allow() {
+			return nil, nil, ErrCircuitOpen
+		}
+
+		reqBody := staticBody
+		if getBody != nil {
+			rc, err := getBody()
+			if err != nil {
+				return nil, nil, err
+			}
+			reqBody = rc
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, finalURL, reqBody)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// base headers
+		for k, v := range c.baseHeaders {
+			req.Header.Set(k, v)
+		}
+		// per-request headers
+		for k, v := range opt.Headers {
+			req.Header.Set(k, v)
+		}
+		// cookies (optional)
+		for _, ck := range opt.Cookies {
+			req.AddCookie(ck)
+		}
+
+		var debugReq []byte
+		if c.debug {
+			debugReq, _ = httputil.DumpRequestOut(req, true)
+		}
+
+		start := time.Now()
+		resp, doErr := c.http.Do(req)
+		if c.proxyPool != nil {
+			c.proxyPool.report(ctx, time.Since(start), doErr)
+		}
+
+		retryable := false
+		if doErr != nil {
+			retryable = isRetryableError(doErr)
+		} else if c.retryCfg.isRetryableStatus(resp.StatusCode) {
+			retryable = true
+		}
+
+		if !retryable {
+			if breaker != nil {
+				if doErr != nil {
+					breaker.recordFailure()
+				} else {
+					breaker.recordSuccess()
+				}
+			}
+			return resp, debugReq, doErr
+		}
+
+		if breaker != nil {
+			breaker.recordFailure()
+		}
+		if attempt >= c.retryCfg.maxRetries {
+			return resp, debugReq, doErr
+		}
+
+		delay := backoff(attempt, c.retryCfg.baseDelay, c.retryCfg.maxDelay)
+		if resp != nil {
+			if c.retryCfg.respectRetryAfter {
+				if ra, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+					delay = ra
+				}
+			}
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
 }
 
-func (c *Client) Get(ctx context.Context, rawURL string, opt Options) (*http.Response, error) {
+// prepareBody resolves how the request body should be (re)produced for
+// each attempt. When the caller wired up Options.GetBody, every attempt
+// takes its body from GetBody and body itself is never read. When
+// retries are disabled, it passes body through unchanged. Otherwise it
+// buffers body in memory so it can be replayed on retry.
+func prepareBody(body io.Reader, opt Options, needsReplay bool) (getBody func() (io.ReadCloser, error), staticBody io.Reader, err error) {
+	if body == nil {
+		return nil, nil, nil
+	}
+	if opt.GetBody != nil {
+		return opt.GetBody, nil, nil
+	}
+	if !needsReplay {
+		return nil, body, nil
+	}
+
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(b)), nil
+	}, nil, nil
+}
+
+func (c *Client) Get(ctx context.Context, rawURL string, opt Options) (*Response, error) {
 	return c.Do(ctx, http.MethodGet, rawURL, nil, opt)
 }
 
-func (c *Client) Post(ctx context.Context, rawURL string, body io.Reader, opt Options) (*http.Response, error) {
+func (c *Client) Post(ctx context.Context, rawURL string, body io.Reader, opt Options) (*Response, error) {
 	return c.Do(ctx, http.MethodPost, rawURL, body, opt)
 }
 
 // PostJSON:  marshal + set Content-Type + decode（out can be nil）
-func (c *Client) PostJSON(ctx context.Context, rawURL string, payload any, out any, opt Options) (*http.Response, error) {
-	b, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err
+func (c *Client) PostJSON(ctx context.Context, rawURL string, payload any, out any, opt Options) (*Response, error) {
+	return c.Request(ctx, http.MethodPost, rawURL, payload, out, opt)
+}
+
+// Request is the generic, codec-driven counterpart to PostJSON: in is
+// serialized with the Encoder registered for opt.ContentType (default
+// application/json) and the response is deserialized into out with the
+// Decoder matching the response's Content-Type (falling back to
+// opt.Accept). Passing a MultipartForm as in switches to
+// multipart/form-data regardless of opt.ContentType.
+func (c *Client) Request(ctx context.Context, method, rawURL string, in, out any, opt Options) (*Response, error) {
+	var body io.Reader
+	contentType := opt.ContentType
+
+	if form, ok := in.(MultipartForm); ok {
+		// Streamed rather than buffered: Files/Streams can be
+		// multi-hundred-MB, and buffering them through the shared
+		// sync.Pool would hand that oversized backing array back out
+		// to an unrelated small JSON encode afterwards.
+		body, contentType = streamMultipart(form)
+	} else if in != nil {
+		buf := GetBuffer()
+		defer PutBuffer(buf)
+
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		enc, err := encoderFor(contentType)
+		if err != nil {
+			return nil, err
+		}
+		if err := enc.Encode(buf, in); err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(buf.Bytes())
 	}
+
 	if opt.Headers == nil {
 		opt.Headers = map[string]string{}
 	}
-	if opt.Headers["Content-Type"] == "" {
-		opt.Headers["Content-Type"] = "application/json"
+	if contentType != "" && opt.Headers["Content-Type"] == "" {
+		opt.Headers["Content-Type"] = contentType
+	}
+	if opt.Accept != "" && opt.Headers["Accept"] == "" {
+		opt.Headers["Accept"] = opt.Accept
 	}
 
-	resp, err := c.Do(ctx, http.MethodPost, rawURL, bytes.NewReader(b), opt)
+	resp, err := c.Do(ctx, method, rawURL, body, opt)
 	if err != nil {
 		return nil, err
 	}
 
 	if out != nil {
-		defer resp.Body.Close()
-		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		dec, err := decoderFor(firstNonEmpty(resp.Header.Get("Content-Type"), opt.Accept))
+		if err != nil {
+			return nil, err
+		}
+		if err := dec.Decode(bytes.NewReader(resp.body), out); err != nil {
 			return nil, err
 		}
 	}
 	return resp, nil
 }
 
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
 func applyQuery(rawURL string, query map[string]string) (string, error) {
 	if len(query) == 0 {
 		return rawURL, nil
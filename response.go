@@ -0,0 +1,158 @@
+package httpclient
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"os"
+
+	"github.com/andybalholm/brotli"
+)
+
+// ErrResponseTooLarge is never returned directly; check Response.Truncated
+// instead. It documents why Bytes/Text/JSON/SaveTo only ever see up to
+// Config.MaxResponseBytes of a larger body.
+var ErrResponseTooLarge = errors.New("httpclient: response exceeded MaxResponseBytes")
+
+// countingReader tracks how many bytes have been read off r, so
+// callers can distinguish "nothing was ever sent" from "a stream was
+// cut short" regardless of what Content-Length claimed.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Response wraps *http.Response with ergonomic helpers. The body is
+// read fully up front, transparently decoding Content-Encoding
+// gzip/deflate/br and capped at the client's MaxResponseBytes, so
+// Text/JSON/XML/Bytes can be called (repeatedly, in any order) without
+// callers managing resp.Body themselves.
+type Response struct {
+	*http.Response
+
+	body []byte
+	// Truncated is true when the body was longer than
+	// Config.MaxResponseBytes and got cut off.
+	Truncated bool
+
+	// DebugRequest/DebugResponse hold the dumped wire request/response
+	// when the client was built with Config.Debug set; nil otherwise.
+	DebugRequest  []byte
+	DebugResponse []byte
+}
+
+// wrapResponse drains, decompresses, and caps resp.Body, returning the
+// ergonomic Response wrapper. debugReq is the dump captured before the
+// request was sent (nil unless c.debug).
+func (c *Client) wrapResponse(resp *http.Response, debugReq []byte) (*Response, error) {
+	r := &Response{Response: resp, DebugRequest: debugReq}
+
+	if c.debug {
+		if dump, err := httputil.DumpResponse(resp, true); err == nil {
+			r.DebugResponse = dump
+		}
+	}
+
+	encoding := resp.Header.Get("Content-Encoding")
+
+	// rawBody counts bytes actually read off the wire, independent of
+	// what Content-Length claimed, so the EOF handling below can tell
+	// a HEAD/204/304 with a stale Content-Encoding header (zero raw
+	// bytes) apart from a genuine mid-stream cutoff of a non-empty
+	// compressed body (which raises the exact same decompressor
+	// errors but has raw bytes behind it).
+	rawBody := &countingReader{r: resp.Body}
+
+	var reader io.Reader = rawBody
+	switch encoding {
+	case "gzip":
+		gz, err := gzip.NewReader(rawBody)
+		if err != nil {
+			if rawBody.n == 0 {
+				resp.Body.Close()
+				return r, nil
+			}
+			resp.Body.Close()
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+	case "deflate":
+		fl := flate.NewReader(rawBody)
+		defer fl.Close()
+		reader = fl
+	case "br":
+		reader = brotli.NewReader(rawBody)
+	}
+
+	if c.maxResponseBytes > 0 {
+		reader = io.LimitReader(reader, c.maxResponseBytes+1)
+	}
+
+	body, err := io.ReadAll(reader)
+	resp.Body.Close()
+	if err != nil {
+		if encoding != "" && errors.Is(err, io.ErrUnexpectedEOF) && rawBody.n == 0 {
+			// Same empty-body-with-stale-header case as above, just
+			// surfaced by deflate/brotli at read time instead of at
+			// NewReader time.
+			body = nil
+		} else {
+			return nil, err
+		}
+	}
+
+	if c.maxResponseBytes > 0 && int64(len(body)) > c.maxResponseBytes {
+		body = body[:c.maxResponseBytes]
+		r.Truncated = true
+	}
+
+	r.body = body
+	return r, nil
+}
+
+// Bytes returns the (already decompressed, size-capped) response body.
+func (r *Response) Bytes() ([]byte, error) {
+	return r.body, nil
+}
+
+// Text returns the response body as a string.
+func (r *Response) Text() (string, error) {
+	return string(r.body), nil
+}
+
+// JSON decodes the response body as JSON into v.
+func (r *Response) JSON(v any) error {
+	return json.Unmarshal(r.body, v)
+}
+
+// XML decodes the response body as XML into v.
+func (r *Response) XML(v any) error {
+	return xml.Unmarshal(r.body, v)
+}
+
+// SaveTo writes the response body to path.
+func (r *Response) SaveTo(path string) error {
+	return os.WriteFile(path, r.body, 0o644)
+}
+
+// Cookies returns the response's Set-Cookie headers as a name->value
+// map. Use r.Response.Cookies() for the full []*http.Cookie.
+func (r *Response) Cookies() map[string]string {
+	out := map[string]string{}
+	for _, ck := range r.Response.Cookies() {
+		out[ck.Name] = ck.Value
+	}
+	return out
+}
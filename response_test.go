@@ -0,0 +1,76 @@
+package httpclient
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+// TestDoDecompressesRealRoundTrip exercises gzip, deflate, and br
+// against a real httptest.Server (not a hand-built *http.Response), so
+// each compressed body travels through the Transport before
+// wrapResponse decodes it.
+func TestDoDecompressesRealRoundTrip(t *testing.T) {
+	const want = "the quick brown fox jumps over the lazy dog"
+
+	cases := []struct {
+		encoding string
+		compress func([]byte) []byte
+	}{
+		{"gzip", func(b []byte) []byte {
+			var buf bytes.Buffer
+			gz := gzip.NewWriter(&buf)
+			gz.Write(b)
+			gz.Close()
+			return buf.Bytes()
+		}},
+		{"deflate", func(b []byte) []byte {
+			var buf bytes.Buffer
+			fl, _ := flate.NewWriter(&buf, flate.DefaultCompression)
+			fl.Write(b)
+			fl.Close()
+			return buf.Bytes()
+		}},
+		{"br", func(b []byte) []byte {
+			var buf bytes.Buffer
+			bw := brotli.NewWriter(&buf)
+			bw.Write(b)
+			bw.Close()
+			return buf.Bytes()
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.encoding, func(t *testing.T) {
+			body := tc.compress([]byte(want))
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Encoding", tc.encoding)
+				w.Write(body)
+			}))
+			defer srv.Close()
+
+			c, err := New(Config{})
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+
+			resp, err := c.Get(context.Background(), srv.URL, Options{})
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			got, err := resp.Text()
+			if err != nil {
+				t.Fatalf("Text: %v", err)
+			}
+			if got != want {
+				t.Fatalf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package httpclient
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrCircuitOpen is returned by Do when the circuit breaker for the
+// request's host is open and the call is being short-circuited.
+var ErrCircuitOpen = errors.New("httpclient: circuit open")
+
+// retryConfig is the resolved, ready-to-use form of the retry-related
+// Config fields.
+type retryConfig struct {
+	maxRetries        int
+	baseDelay         time.Duration
+	maxDelay          time.Duration
+	retryableStatuses map[int]struct{}
+	respectRetryAfter bool
+}
+
+func newRetryConfig(cfg Config) retryConfig {
+	statuses := make(map[int]struct{}, len(cfg.RetryableStatuses))
+	for _, s := range cfg.RetryableStatuses {
+		statuses[s] = struct{}{}
+	}
+	return retryConfig{
+		maxRetries:        cfg.MaxRetries,
+		baseDelay:         cfg.RetryBaseDelay,
+		maxDelay:          cfg.RetryMaxDelay,
+		retryableStatuses: statuses,
+		respectRetryAfter: cfg.RespectRetryAfter,
+	}
+}
+
+func (rc retryConfig) isRetryableStatus(code int) bool {
+	_, ok := rc.retryableStatuses[code]
+	return ok
+}
+
+// isRetryableError reports whether err looks like a transient network
+// failure (timeout, connection reset/refused) worth retrying.
+func isRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr)
+}
+
+// backoff returns the delay before the given 0-indexed retry attempt:
+// exponential growth from base, capped at max, then a random delay
+// between half of that value and all of it.
+func backoff(attempt int, base, max time.Duration) time.Duration {
+	d := base * time.Duration(uint64(1)<<uint(attempt))
+	if d <= 0 || d > max {
+		d = max
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2+1)))
+}
+
+// parseRetryAfter parses a Retry-After header value, which per RFC 7231
+// is either a number of delta-seconds or an HTTP-date.
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			return 0, false
+		}
+		return d, true
+	}
+	return 0, false
+}
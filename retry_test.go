@@ -0,0 +1,74 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestDoRetriesRetryableStatus verifies doRaw retries a default
+// retryable status until the server starts succeeding.
+func TestDoRetriesRetryableStatus(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		if n < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c, err := New(Config{
+		MaxRetries:     5,
+		RetryBaseDelay: time.Millisecond,
+		RetryMaxDelay:  5 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	resp, err := c.Get(context.Background(), srv.URL, Options{})
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected eventual 200, got %d", resp.StatusCode)
+	}
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Fatalf("expected 3 attempts, got %d", got)
+	}
+}
+
+// TestDoCircuitBreakerTrips verifies a host that keeps failing gets
+// short-circuited with ErrCircuitOpen instead of hammering the server
+// forever.
+func TestDoCircuitBreakerTrips(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	c, err := New(Config{
+		CircuitBreakerThreshold:  0.5,
+		CircuitBreakerMinSamples: 2,
+		CircuitBreakerCoolDown:   time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.Get(context.Background(), srv.URL, Options{}); err != nil {
+			t.Fatalf("attempt %d: unexpected error %v", i, err)
+		}
+	}
+
+	if _, err := c.Get(context.Background(), srv.URL, Options{}); err != ErrCircuitOpen {
+		t.Fatalf("expected ErrCircuitOpen once the breaker trips, got %v", err)
+	}
+}